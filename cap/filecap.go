@@ -0,0 +1,234 @@
+package cap
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// xattrNameCaps is the extended attribute libcap uses to store a
+// file's capabilities on disk.
+const xattrNameCaps = "security.capability"
+
+// On disk file capability layout. See uapi/linux/capability.h:
+// struct vfs_cap_data holds a magic_etc word followed by
+// vfsCapU32 (permitted, inheritable) pairs; struct vfs_ns_cap_data
+// additionally appends a little-endian rootid for VFS_CAP_REVISION_3.
+const (
+	vfsCapRevisionMask   = 0xff000000
+	vfsCapFlagsEffective = 0x000001
+
+	vfsCapRevision2 = 0x02000000
+	vfsCapRevision3 = 0x03000000
+
+	vfsCapU32 = 2 // number of 32-bit words per flag vector on disk.
+)
+
+// ErrBadFileCap indicates the bytes of a security.capability
+// extended attribute could not be parsed as a supported revision.
+var ErrBadFileCap = errors.New("unsupported or corrupt file capability")
+
+// NewFileCap returns an empty capability Set suitable for populating
+// and writing out via SetFile or SetFd.
+func NewFileCap() *Set {
+	return NewSet()
+}
+
+// decodeFileCap parses the raw bytes of a security.capability
+// extended attribute into a capability Set. The Set's GetNSOwner
+// value is populated from a VFS_CAP_REVISION_3 rootid, if present.
+func decodeFileCap(raw []byte) (*Set, error) {
+	if len(raw) < 4 {
+		return nil, ErrBadFileCap
+	}
+	magic := binary.LittleEndian.Uint32(raw[0:4])
+	c := NewSet()
+	switch magic & vfsCapRevisionMask {
+	case vfsCapRevision2:
+		if len(raw) != 4+8*vfsCapU32 {
+			return nil, ErrBadFileCap
+		}
+	case vfsCapRevision3:
+		if len(raw) != 4+8*vfsCapU32+4 {
+			return nil, ErrBadFileCap
+		}
+		c.nsRoot = int(binary.LittleEndian.Uint32(raw[4+8*vfsCapU32:]))
+	default:
+		return nil, ErrBadFileCap
+	}
+	for i := 0; i < vfsCapU32 && i < len(c.flat); i++ {
+		base := 4 + 8*i
+		c.flat[i][Permitted] = binary.LittleEndian.Uint32(raw[base : base+4])
+		c.flat[i][Inheritable] = binary.LittleEndian.Uint32(raw[base+4 : base+8])
+	}
+	if magic&vfsCapFlagsEffective != 0 {
+		for i := range c.flat {
+			c.flat[i][Effective] = c.flat[i][Permitted]
+		}
+	}
+	return c, nil
+}
+
+// encodeFileCap serializes c into the on disk file capability
+// payload. VFS_CAP_REVISION_3 (rootid tagged) is emitted when c's
+// nsRoot is non-zero, otherwise the plain VFS_CAP_REVISION_2 layout
+// is used.
+func encodeFileCap(c *Set) ([]byte, error) {
+	if c == nil || len(c.flat) == 0 {
+		return nil, ErrBadSet
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rev := uint32(vfsCapRevision2)
+	size := 4 + 8*vfsCapU32
+	if c.nsRoot != 0 {
+		rev = vfsCapRevision3
+		size += 4
+	}
+	magic := rev
+	for i := 0; i < vfsCapU32 && i < len(c.flat); i++ {
+		if c.flat[i][Effective] != 0 {
+			magic |= vfsCapFlagsEffective
+			break
+		}
+	}
+	raw := make([]byte, size)
+	binary.LittleEndian.PutUint32(raw[0:4], magic)
+	for i := 0; i < vfsCapU32; i++ {
+		base := 4 + 8*i
+		if i < len(c.flat) {
+			binary.LittleEndian.PutUint32(raw[base:base+4], c.flat[i][Permitted])
+			binary.LittleEndian.PutUint32(raw[base+4:base+8], c.flat[i][Inheritable])
+		}
+	}
+	if rev == vfsCapRevision3 {
+		binary.LittleEndian.PutUint32(raw[4+8*vfsCapU32:], uint32(c.nsRoot))
+	}
+	return raw, nil
+}
+
+// getxattr reads the named extended attribute of path, growing the
+// read buffer as needed.
+func getxattr(path, name string) ([]byte, error) {
+	for size := 128; ; size *= 2 {
+		buf := make([]byte, size)
+		n, err := syscall.Getxattr(path, name, buf)
+		if err == syscall.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// fgetxattr reads the named extended attribute of the already open
+// file f. The syscall package does not expose Fgetxattr, so this
+// wraps the raw system call directly, in keeping with this package's
+// no-cgo design.
+func fgetxattr(f *os.File, name string) ([]byte, error) {
+	nameB, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	for size := 128; ; size *= 2 {
+		buf := make([]byte, size)
+		r, _, errno := syscall.Syscall6(syscall.SYS_FGETXATTR, f.Fd(),
+			uintptr(unsafe.Pointer(nameB)), uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(size), 0, 0)
+		if errno == syscall.ERANGE {
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		return buf[:r], nil
+	}
+}
+
+// fsetxattr writes the named extended attribute of the already open
+// file f.
+func fsetxattr(f *os.File, name string, value []byte) error {
+	nameB, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_FSETXATTR, f.Fd(),
+		uintptr(unsafe.Pointer(nameB)), uintptr(unsafe.Pointer(&value[0])),
+		uintptr(len(value)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// GetFile reads the security.capability extended attribute of path
+// and decodes it into a capability Set.
+func GetFile(path string) (*Set, error) {
+	raw, err := getxattr(path, xattrNameCaps)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFileCap(raw)
+}
+
+// GetFd behaves like GetFile but operates on an already open file.
+func GetFd(f *os.File) (*Set, error) {
+	raw, err := fgetxattr(f, xattrNameCaps)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFileCap(raw)
+}
+
+// SetFile writes c to path's security.capability extended attribute,
+// replacing any value already present. Only the Permitted and
+// Inheritable flags, plus a single "raise Effective on exec" bit
+// derived from Effective, are representable on disk.
+func (c *Set) SetFile(path string) error {
+	raw, err := encodeFileCap(c)
+	if err != nil {
+		return err
+	}
+	return syscall.Setxattr(path, xattrNameCaps, raw, 0)
+}
+
+// SetFd behaves like SetFile but operates on an already open file.
+func (c *Set) SetFd(f *os.File) error {
+	raw, err := encodeFileCap(c)
+	if err != nil {
+		return err
+	}
+	return fsetxattr(f, xattrNameCaps, raw)
+}
+
+// Compare reports whether two capability Sets hold identical flag
+// vectors and namespace root. It is primarily useful for deciding
+// whether a SetFile/SetFd write is actually necessary.
+func (c *Set) Compare(d *Set) (bool, error) {
+	if c == nil || d == nil || len(c.flat) == 0 || len(d.flat) == 0 {
+		return false, ErrBadSet
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c != d {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+	}
+	if c.nsRoot != d.nsRoot {
+		return false, nil
+	}
+	n := len(c.flat)
+	if len(d.flat) < n {
+		n = len(d.flat)
+	}
+	for i := 0; i < n; i++ {
+		if c.flat[i] != d.flat[i] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,130 @@
+package cap
+
+import "testing"
+
+func TestFileCapRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		permitted   []Value
+		inheritable []Value
+		effective   bool
+		nsRoot      int
+	}{
+		{
+			name:      "v2 permitted only",
+			permitted: []Value{CHOWN, SYS_ADMIN},
+		},
+		{
+			name:        "v2 permitted and inheritable with effective",
+			permitted:   []Value{NET_BIND_SERVICE, SETUID},
+			inheritable: []Value{NET_BIND_SERVICE},
+			effective:   true,
+		},
+		{
+			name:      "v3 with nsRoot",
+			permitted: []Value{SYS_RESOURCE},
+			nsRoot:    1000,
+		},
+		{
+			name:        "v3 permitted, inheritable and effective",
+			permitted:   []Value{KILL, SYS_NICE},
+			inheritable: []Value{KILL},
+			effective:   true,
+			nsRoot:      12345,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewFileCap()
+			if err := c.SetFlag(Permitted, true, tc.permitted...); err != nil {
+				t.Fatalf("SetFlag(Permitted): %v", err)
+			}
+			if err := c.SetFlag(Inheritable, true, tc.inheritable...); err != nil {
+				t.Fatalf("SetFlag(Inheritable): %v", err)
+			}
+			if tc.effective {
+				if err := c.SetFlag(Effective, true, tc.permitted...); err != nil {
+					t.Fatalf("SetFlag(Effective): %v", err)
+				}
+			}
+			if err := c.SetNSOwner(tc.nsRoot); err != nil {
+				t.Fatalf("SetNSOwner: %v", err)
+			}
+
+			raw, err := encodeFileCap(c)
+			if err != nil {
+				t.Fatalf("encodeFileCap: %v", err)
+			}
+			wantLen := 4 + 8*vfsCapU32
+			if tc.nsRoot != 0 {
+				wantLen += 4
+			}
+			if len(raw) != wantLen {
+				t.Fatalf("encodeFileCap length = %d, want %d", len(raw), wantLen)
+			}
+
+			back, err := decodeFileCap(raw)
+			if err != nil {
+				t.Fatalf("decodeFileCap: %v", err)
+			}
+			same, err := c.Compare(back)
+			if err != nil {
+				t.Fatalf("Compare: %v", err)
+			}
+			if !same {
+				t.Errorf("decodeFileCap(encodeFileCap(c)) != c: got %q, want %q", back, c)
+			}
+			owner, err := back.GetNSOwner()
+			if err != nil {
+				t.Fatalf("GetNSOwner: %v", err)
+			}
+			if owner != tc.nsRoot {
+				t.Errorf("GetNSOwner() = %d, want %d", owner, tc.nsRoot)
+			}
+		})
+	}
+}
+
+func TestDecodeFileCapBad(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"too short", []byte{0x01}},
+		{"unknown revision", []byte{0x00, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"wrong length for v2", append([]byte{0x00, 0x00, 0x00, 0x02}, make([]byte, 4)...)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := decodeFileCap(tc.raw); err != ErrBadFileCap {
+				t.Errorf("decodeFileCap(%v) error = %v, want ErrBadFileCap", tc.raw, err)
+			}
+		})
+	}
+}
+
+func TestSetFileCapCompare(t *testing.T) {
+	a := NewFileCap()
+	if err := a.SetFlag(Permitted, true, CHOWN); err != nil {
+		t.Fatalf("SetFlag: %v", err)
+	}
+	b, err := a.Dup()
+	if err != nil {
+		t.Fatalf("Dup: %v", err)
+	}
+	same, err := a.Compare(b)
+	if err != nil || !same {
+		t.Fatalf("Compare(dup) = %v, %v, want true, nil", same, err)
+	}
+	if err := b.SetFlag(Permitted, true, SYS_ADMIN); err != nil {
+		t.Fatalf("SetFlag: %v", err)
+	}
+	same, err = a.Compare(b)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if same {
+		t.Errorf("Compare() = true after diverging b, want false")
+	}
+}
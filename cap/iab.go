@@ -0,0 +1,261 @@
+package cap
+
+import (
+	"strings"
+	"sync"
+)
+
+// IABFlag identifies one of the three vectors held in an IAB.
+type IABFlag uint
+
+// The three vectors tracked by an IAB.
+const (
+	IABInh IABFlag = iota
+	IABAmb
+	IABBound
+)
+
+// IAB packages an Inheritable vector, an Ambient vector and a
+// bounding-drop vector as a single value, the way libcap's cap_iab_t
+// does. It gives a Launcher (or a direct SetProc call) a single
+// object to describe the "inheritable+ambient with a bounding fence"
+// pattern used to hand a child process a capability without
+// setuid-root or file capabilities.
+type IAB struct {
+	mu  sync.RWMutex
+	vec [3][]uint32
+}
+
+// newIAB allocates an IAB with correctly sized, zeroed vectors for
+// the running kernel.
+func newIAB() *IAB {
+	startUp.Do(cInit)
+	iab := &IAB{}
+	for i := range iab.vec {
+		iab.vec[i] = make([]uint32, words)
+	}
+	return iab
+}
+
+func (iab *IAB) setValue(f IABFlag, enable bool, vals ...Value) error {
+	iab.mu.Lock()
+	defer iab.mu.Unlock()
+	for _, v := range vals {
+		offset, mask, err := bitOf(Effective, v)
+		if err != nil {
+			return err
+		}
+		if enable {
+			iab.vec[f][offset] |= mask
+		} else {
+			iab.vec[f][offset] &= ^mask
+		}
+	}
+	return nil
+}
+
+// values returns the ascending list of Values set in vector f.
+func (iab *IAB) values(f IABFlag) []Value {
+	iab.mu.RLock()
+	defer iab.mu.RUnlock()
+	var out []Value
+	for v := Value(0); v < Value(maxValues); v++ {
+		offset, mask, err := bitOf(Effective, v)
+		if err != nil {
+			break
+		}
+		if iab.vec[f][offset]&mask != 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// NewIAB returns an empty IAB: nothing inheritable, ambient or
+// dropped from the bounding set.
+func NewIAB() *IAB {
+	return newIAB()
+}
+
+// IABGetProc returns the IAB describing the calling process's
+// current Inheritable vector and Ambient set, along with the
+// complement of its bounding set (the capabilities that have already
+// been dropped from it).
+func IABGetProc() *IAB {
+	iab := newIAB()
+	proc := GetProc()
+	for v := Value(0); v < Value(maxValues); v++ {
+		if ok, _ := proc.GetFlag(Inheritable, v); ok {
+			iab.setValue(IABInh, true, v)
+		}
+		if ok, _ := GetAmbient(v); ok {
+			iab.setValue(IABAmb, true, v)
+		}
+		if ok, _ := GetBound(v); !ok {
+			iab.setValue(IABBound, true, v)
+		}
+	}
+	return iab
+}
+
+// SetProc applies iab to the calling process: it raises the
+// Inheritable vector to the union of iab's Inheritable and Ambient
+// vectors (the kernel requires a capability to be Inheritable before
+// it can be raised into the Ambient set), drops the bounding set
+// Values marked in iab, then resets the Ambient set and raises it to
+// iab's Ambient vector. This is the order libcap's cap_iab_set_proc
+// uses, chosen so that an Ambient raise can never race with a
+// capability that hasn't been made Inheritable yet.
+func (iab *IAB) SetProc() error {
+	proc := GetProc()
+	if err := proc.ClearFlag(Inheritable); err != nil {
+		return err
+	}
+	if err := proc.SetFlag(Inheritable, true, iab.inheritableValues()...); err != nil {
+		return err
+	}
+	if err := proc.SetProc(); err != nil {
+		return err
+	}
+	if err := DropBound(iab.values(IABBound)...); err != nil {
+		return err
+	}
+	if err := ResetAmbient(); err != nil {
+		return err
+	}
+	return SetAmbient(true, iab.values(IABAmb)...)
+}
+
+// inheritableValues returns the Values that must be raised into a
+// process's Inheritable vector to apply iab: its own Inheritable
+// vector, unioned with its Ambient vector, since the kernel requires
+// a capability to already be Inheritable before it can be raised
+// into the Ambient set.
+func (iab *IAB) inheritableValues() []Value {
+	return unionValues(iab.values(IABInh), iab.values(IABAmb))
+}
+
+// unionValues returns the deduplicated union of a and b.
+func unionValues(a, b []Value) []Value {
+	seen := make(map[Value]bool, len(a)+len(b))
+	out := make([]Value, 0, len(a)+len(b))
+	for _, vals := range [][]Value{a, b} {
+		for _, v := range vals {
+			if !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+// Fill replaces iab's vec vector with the Values currently set in
+// fromVec of from.
+func (iab *IAB) Fill(vec IABFlag, from *Set, fromVec Flag) error {
+	if from == nil || len(from.flat) == 0 {
+		return ErrBadSet
+	}
+	iab.mu.Lock()
+	for i := range iab.vec[vec] {
+		iab.vec[vec][i] = 0
+	}
+	iab.mu.Unlock()
+	for v := Value(0); v < Value(maxValues); v++ {
+		ok, err := from.GetFlag(fromVec, v)
+		if err != nil {
+			break
+		}
+		if ok {
+			if err := iab.setValue(vec, true, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IABFromText parses libcap's IAB text syntax: a comma separated
+// list of capability names, each optionally prefixed with any
+// combination of "!" (the capability is dropped from the bounding
+// set) and "^" (the capability is ambient, which the kernel requires
+// to also be inheritable). A plain name is Inheritable but not
+// Ambient; bounding-set membership is orthogonal to Inheritable, so a
+// "!"-only token is not implicitly made Inheritable.
+func IABFromText(s string) (*IAB, error) {
+	iab := newIAB()
+	if s == "" {
+		return iab, nil
+	}
+	for _, tok := range strings.Split(s, ",") {
+		if tok == "" {
+			return nil, ErrBadText
+		}
+		bound, amb := false, false
+		for len(tok) > 0 && (tok[0] == '!' || tok[0] == '^') {
+			if tok[0] == '!' {
+				bound = true
+			} else {
+				amb = true
+			}
+			tok = tok[1:]
+		}
+		v, err := FromName(tok)
+		if err != nil {
+			return nil, err
+		}
+		if amb {
+			if err := iab.setValue(IABInh, true, v); err != nil {
+				return nil, err
+			}
+			if err := iab.setValue(IABAmb, true, v); err != nil {
+				return nil, err
+			}
+		} else if !bound {
+			if err := iab.setValue(IABInh, true, v); err != nil {
+				return nil, err
+			}
+		}
+		if bound {
+			if err := iab.setValue(IABBound, true, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return iab, nil
+}
+
+// String renders iab using the syntax accepted by IABFromText, e.g.
+// "^cap_net_bind_service,!cap_sys_admin,cap_kill". A capability that
+// is both Ambient and dropped from the bounding set carries both
+// prefixes; one that is only Inheritable carries neither. The
+// Inheritable bit of a capability dropped from the bounding set is
+// not rendered, so a capability that is both Inheritable and dropped
+// (but not Ambient) is written as "!" alone, which IABFromText reads
+// back as not Inheritable.
+func (iab *IAB) String() string {
+	iab.mu.RLock()
+	defer iab.mu.RUnlock()
+	var parts []string
+	for v := Value(0); v < Value(maxValues); v++ {
+		offset, mask, err := bitOf(Effective, v)
+		if err != nil {
+			break
+		}
+		inh := iab.vec[IABInh][offset]&mask != 0
+		amb := iab.vec[IABAmb][offset]&mask != 0
+		bound := iab.vec[IABBound][offset]&mask != 0
+		if !inh && !amb && !bound {
+			continue
+		}
+		prefix := ""
+		if bound {
+			prefix += "!"
+		}
+		if amb {
+			prefix += "^"
+		}
+		parts = append(parts, prefix+v.String())
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,92 @@
+package cap
+
+import "testing"
+
+func TestIABFromTextAndString(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"plain inheritable only", "cap_kill"},
+		{"ambient implies inheritable", "^cap_net_bind_service"},
+		{"bounding drop only, not inheritable", "!cap_sys_admin"},
+		{"bounding drop and ambient", "!cap_sys_admin,^cap_net_bind_service"},
+		{"mixed", "cap_kill,^cap_net_bind_service,!cap_sys_admin"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			iab, err := IABFromText(tc.text)
+			if err != nil {
+				t.Fatalf("IABFromText(%q): %v", tc.text, err)
+			}
+			rendered := iab.String()
+			back, err := IABFromText(rendered)
+			if err != nil {
+				t.Fatalf("IABFromText(String(iab)) = IABFromText(%q): %v", rendered, err)
+			}
+			for _, f := range []IABFlag{IABInh, IABAmb, IABBound} {
+				got, want := back.values(f), iab.values(f)
+				if !valuesEqual(got, want) {
+					t.Errorf("vector %d round-trip through %q = %v, want %v", f, rendered, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIABFromTextBoundOnlyNotInheritable(t *testing.T) {
+	iab, err := IABFromText("!cap_sys_admin")
+	if err != nil {
+		t.Fatalf("IABFromText: %v", err)
+	}
+	if inh := iab.values(IABInh); len(inh) != 0 {
+		t.Errorf("values(IABInh) = %v, want empty: a bounding-drop-only token must not become Inheritable", inh)
+	}
+	if bound := iab.values(IABBound); !valuesEqual(bound, []Value{SYS_ADMIN}) {
+		t.Errorf("values(IABBound) = %v, want [%v]", bound, SYS_ADMIN)
+	}
+}
+
+func TestIABFromTextAmbientImpliesInheritable(t *testing.T) {
+	iab, err := IABFromText("^cap_net_bind_service")
+	if err != nil {
+		t.Fatalf("IABFromText: %v", err)
+	}
+	if inh := iab.values(IABInh); !valuesEqual(inh, []Value{NET_BIND_SERVICE}) {
+		t.Errorf("values(IABInh) = %v, want [%v]: ambient must imply inheritable", inh, NET_BIND_SERVICE)
+	}
+	if amb := iab.values(IABAmb); !valuesEqual(amb, []Value{NET_BIND_SERVICE}) {
+		t.Errorf("values(IABAmb) = %v, want [%v]", amb, NET_BIND_SERVICE)
+	}
+}
+
+func TestIABInheritableValuesUnion(t *testing.T) {
+	iab := NewIAB()
+	if err := iab.setValue(IABAmb, true, NET_BIND_SERVICE); err != nil {
+		t.Fatalf("setValue(IABAmb): %v", err)
+	}
+	if err := iab.setValue(IABInh, true, SYS_NICE); err != nil {
+		t.Fatalf("setValue(IABInh): %v", err)
+	}
+	got := iab.inheritableValues()
+	want := []Value{SYS_NICE, NET_BIND_SERVICE}
+	if !valuesEqual(got, want) {
+		t.Errorf("inheritableValues() = %v, want the union %v (an Ambient-only capability, set via setValue/Fill rather than IABFromText, must still be raised Inheritable)", got, want)
+	}
+}
+
+func valuesEqual(a, b []Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[Value]bool, len(a))
+	for _, v := range a {
+		seen[v] = true
+	}
+	for _, v := range b {
+		if !seen[v] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package cap
+
+import "syscall"
+
+// callRKernel and callWKernel issue the raw 3-argument system calls
+// used throughout this package to read and write kernel capability
+// state respectively. Keeping them as a single choke point (rather
+// than calling syscall.RawSyscall directly from every call site)
+// leaves room for a future, thread-synchronized implementation
+// without disturbing every caller.
+func callRKernel(call, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	return syscall.RawSyscall(call, a1, a2, a3)
+}
+
+func callWKernel(call, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	return syscall.RawSyscall(call, a1, a2, a3)
+}
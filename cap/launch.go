@@ -0,0 +1,226 @@
+package cap
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// Mode mirrors libcap's cap_mode_t: the broad capability-management
+// strategy a Launcher's child is expected to run under. It is
+// informational - the actual Inheritable/Ambient/Bounding state
+// applied to the child comes from SetIAB - but future callers (and
+// future revisions of this package) can use it to pick sensible
+// defaults.
+type Mode int
+
+// The modes recognized by libcap.
+const (
+	ModeUncertain Mode = iota
+	ModeNoPriv
+	ModePure1E
+	ModeHybrid
+)
+
+// String names a Mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeNoPriv:
+		return "NOPRIV"
+	case ModePure1E:
+		return "PURE1E"
+	case ModeHybrid:
+		return "HYBRID"
+	default:
+		return "UNCERTAIN"
+	}
+}
+
+// Launcher describes a child process to be started with a specific
+// capability profile. Build one with NewLauncher and the chainable
+// setters, then call Launch.
+type Launcher struct {
+	path string
+	args []string
+	env  []string
+
+	uid     int
+	haveUID bool
+	gid     int
+	groups  []int
+	haveGID bool
+
+	mode Mode
+	iab  *IAB
+
+	chroot string
+
+	callback func(pa *syscall.ProcAttr, data interface{}) error
+}
+
+// NewLauncher returns a Launcher that will, when Launch is called,
+// execve path with args and env in a freshly forked child.
+func NewLauncher(path string, args []string, env []string) *Launcher {
+	return &Launcher{path: path, args: args, env: env}
+}
+
+// SetUID arranges for the launched child to run as uid.
+func (l *Launcher) SetUID(uid int) *Launcher {
+	l.uid = uid
+	l.haveUID = true
+	return l
+}
+
+// SetGroups arranges for the launched child to run as gid, with the
+// supplied supplementary groups.
+func (l *Launcher) SetGroups(gid int, groups []int) *Launcher {
+	l.gid = gid
+	l.groups = groups
+	l.haveGID = true
+	return l
+}
+
+// SetMode selects the high level capability-management mode the
+// child is being launched into. See Mode.
+func (l *Launcher) SetMode(m Mode) *Launcher {
+	l.mode = m
+	return l
+}
+
+// SetIAB attaches the Inheritable/Ambient/Bounding profile the child
+// should be started with.
+func (l *Launcher) SetIAB(iab *IAB) *Launcher {
+	l.iab = iab
+	return l
+}
+
+// SetChroot arranges for the child to chroot(2) to dir before it
+// execs.
+func (l *Launcher) SetChroot(dir string) *Launcher {
+	l.chroot = dir
+	return l
+}
+
+// Callback registers a function invoked with the syscall.ProcAttr
+// that is about to be used to launch the child, and the opaque data
+// passed to Launch, so a caller can tweak attributes (working
+// directory, extra files) this package doesn't otherwise expose.
+func (l *Launcher) Callback(cb func(pa *syscall.ProcAttr, data interface{}) error) *Launcher {
+	l.callback = cb
+	return l
+}
+
+// Launch starts the configured program in a new process, returning
+// its pid.
+//
+// The credential and capability changes (bounding drops, the
+// Inheritable capability Set, setgroups/setresuid/setresgid, and the
+// ambient raises) are all committed on a single OS thread that is
+// then discarded, never returned to the Go scheduler, so that they
+// can never be observed or raced with by another goroutine running
+// on this process's other threads. This mirrors the intent of
+// libcap's cap_launch, adapted to what Go's runtime safely exposes
+// for the fork+exec transition: setgroups, setresuid/setresgid and
+// the ambient raises all happen inside syscall.ForkExec's own
+// child-only code path (via Credential.Groups, Credential.Uid/Gid
+// and AmbientCaps), while the bounding drops and the Inheritable Set
+// are applied to the parent-side thread immediately beforehand,
+// since that thread's (now-lowered) credentials are what the forked
+// child inherits. An unset SetUID or SetGroups defaults to the
+// caller's current Geteuid/Getegid, so setting only one of the two
+// never forces the other to root.
+func (l *Launcher) Launch(data interface{}) (int, error) {
+	pa := &syscall.ProcAttr{
+		Env: l.env,
+	}
+	if l.callback != nil {
+		if err := l.callback(pa, data); err != nil {
+			return -1, err
+		}
+	}
+
+	type result struct {
+		pid int
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.Goexit()
+		pid, err := l.launch(pa)
+		ch <- result{pid, err}
+	}()
+	r := <-ch
+	return r.pid, r.err
+}
+
+// inheritableValues returns the Values that must be raised into the
+// child's Inheritable vector for l.iab (or nil if l has no IAB). It
+// delegates to (*IAB).inheritableValues so Launch and (*IAB).SetProc
+// can never disagree about the Inheritable/Ambient union.
+func (l *Launcher) inheritableValues() []Value {
+	if l.iab == nil {
+		return nil
+	}
+	return l.iab.inheritableValues()
+}
+
+// launch performs the privileged part of Launch on the calling
+// (locked, soon to be discarded) OS thread.
+func (l *Launcher) launch(pa *syscall.ProcAttr) (int, error) {
+	if l.iab != nil {
+		if drops := l.iab.values(IABBound); len(drops) > 0 {
+			if err := DropBound(drops...); err != nil {
+				return -1, err
+			}
+		}
+		proc := GetProc()
+		if err := proc.SetFlag(Inheritable, true, l.inheritableValues()...); err != nil {
+			return -1, err
+		}
+		if err := proc.SetProc(); err != nil {
+			return -1, err
+		}
+	}
+
+	if l.haveUID || l.haveGID {
+		uid, gid := l.uid, l.gid
+		if !l.haveUID {
+			uid = syscall.Geteuid()
+		}
+		if !l.haveGID {
+			gid = syscall.Getegid()
+		}
+		groups := make([]uint32, len(l.groups))
+		for i, g := range l.groups {
+			groups[i] = uint32(g)
+		}
+		if pa.Sys == nil {
+			pa.Sys = &syscall.SysProcAttr{}
+		}
+		pa.Sys.Credential = &syscall.Credential{
+			Uid:    uint32(uid),
+			Gid:    uint32(gid),
+			Groups: groups,
+		}
+	}
+	if l.iab != nil {
+		if amb := l.iab.values(IABAmb); len(amb) > 0 {
+			if pa.Sys == nil {
+				pa.Sys = &syscall.SysProcAttr{}
+			}
+			caps := make([]uintptr, len(amb))
+			for i, v := range amb {
+				caps[i] = uintptr(v)
+			}
+			pa.Sys.AmbientCaps = caps
+		}
+	}
+	if l.chroot != "" {
+		if pa.Sys == nil {
+			pa.Sys = &syscall.SysProcAttr{}
+		}
+		pa.Sys.Chroot = l.chroot
+	}
+
+	return syscall.ForkExec(l.path, l.args, pa)
+}
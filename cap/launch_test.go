@@ -0,0 +1,47 @@
+package cap
+
+import "testing"
+
+func TestNewLauncherChaining(t *testing.T) {
+	l := NewLauncher("/bin/true", []string{"/bin/true"}, []string{"PATH=/bin"}).
+		SetUID(1000).
+		SetGroups(1000, []int{100, 200}).
+		SetMode(ModeHybrid).
+		SetChroot("/var/empty")
+	if l.path != "/bin/true" || !l.haveUID || l.uid != 1000 || !l.haveGID || l.gid != 1000 {
+		t.Fatalf("Launcher fields not set as expected: %+v", l)
+	}
+	if l.chroot != "/var/empty" {
+		t.Errorf("chroot = %q, want /var/empty", l.chroot)
+	}
+}
+
+// TestLauncherInheritableUnion exercises the same computation Launch
+// performs before handing AmbientCaps to syscall.ForkExec, without
+// actually forking: an Ambient-only capability (as Fill or setValue
+// can produce, not just IABFromText) must still appear in the set
+// raised into the child's Inheritable vector, or the kernel will
+// refuse the Ambient raise with EPERM.
+func TestLauncherInheritableUnion(t *testing.T) {
+	iab := NewIAB()
+	if err := iab.setValue(IABAmb, true, NET_BIND_SERVICE); err != nil {
+		t.Fatalf("setValue(IABAmb): %v", err)
+	}
+	if err := iab.setValue(IABInh, true, SYS_NICE); err != nil {
+		t.Fatalf("setValue(IABInh): %v", err)
+	}
+
+	l := NewLauncher("/bin/true", []string{"/bin/true"}, nil).SetIAB(iab)
+	got := l.inheritableValues()
+	want := []Value{SYS_NICE, NET_BIND_SERVICE}
+	if !valuesEqual(got, want) {
+		t.Errorf("inheritableValues() = %v, want the union %v", got, want)
+	}
+}
+
+func TestLauncherInheritableUnionNoIAB(t *testing.T) {
+	l := NewLauncher("/bin/true", []string{"/bin/true"}, nil)
+	if got := l.inheritableValues(); len(got) != 0 {
+		t.Errorf("inheritableValues() with no IAB = %v, want empty", got)
+	}
+}
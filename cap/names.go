@@ -0,0 +1,109 @@
+package cap
+
+// This file associates a name with every Value known at the time
+// this package was built, the way libcap's own generated cap_names.h
+// does. Regenerate it against a newer uapi/linux/capability.h when
+// the kernel grows new capabilities.
+
+// The named capability Values, in their fixed kernel bit order.
+const (
+	CHOWN Value = iota
+	DAC_OVERRIDE
+	DAC_READ_SEARCH
+	FOWNER
+	FSETID
+	KILL
+	SETGID
+	SETUID
+	SETPCAP
+	LINUX_IMMUTABLE
+	NET_BIND_SERVICE
+	NET_BROADCAST
+	NET_ADMIN
+	NET_RAW
+	IPC_LOCK
+	IPC_OWNER
+	SYS_MODULE
+	SYS_RAWIO
+	SYS_CHROOT
+	SYS_PTRACE
+	SYS_PACCT
+	SYS_ADMIN
+	SYS_BOOT
+	SYS_NICE
+	SYS_RESOURCE
+	SYS_TIME
+	SYS_TTY_CONFIG
+	MKNOD
+	LEASE
+	AUDIT_WRITE
+	AUDIT_CONTROL
+	SETFCAP
+	MAC_OVERRIDE
+	MAC_ADMIN
+	SYSLOG
+	WAKE_ALARM
+	BLOCK_SUSPEND
+	AUDIT_READ
+	PERFMON
+	BPF
+	CHECKPOINT_RESTORE
+)
+
+// NamedCount is the number of Values named below.
+const NamedCount = 41
+
+// valueToName maps a Value to its canonical "cap_foo" text form. It
+// is indexed directly by Value.
+var valueToName = [NamedCount]string{
+	CHOWN:              "cap_chown",
+	DAC_OVERRIDE:       "cap_dac_override",
+	DAC_READ_SEARCH:    "cap_dac_read_search",
+	FOWNER:             "cap_fowner",
+	FSETID:             "cap_fsetid",
+	KILL:               "cap_kill",
+	SETGID:             "cap_setgid",
+	SETUID:             "cap_setuid",
+	SETPCAP:            "cap_setpcap",
+	LINUX_IMMUTABLE:    "cap_linux_immutable",
+	NET_BIND_SERVICE:   "cap_net_bind_service",
+	NET_BROADCAST:      "cap_net_broadcast",
+	NET_ADMIN:          "cap_net_admin",
+	NET_RAW:            "cap_net_raw",
+	IPC_LOCK:           "cap_ipc_lock",
+	IPC_OWNER:          "cap_ipc_owner",
+	SYS_MODULE:         "cap_sys_module",
+	SYS_RAWIO:          "cap_sys_rawio",
+	SYS_CHROOT:         "cap_sys_chroot",
+	SYS_PTRACE:         "cap_sys_ptrace",
+	SYS_PACCT:          "cap_sys_pacct",
+	SYS_ADMIN:          "cap_sys_admin",
+	SYS_BOOT:           "cap_sys_boot",
+	SYS_NICE:           "cap_sys_nice",
+	SYS_RESOURCE:       "cap_sys_resource",
+	SYS_TIME:           "cap_sys_time",
+	SYS_TTY_CONFIG:     "cap_sys_tty_config",
+	MKNOD:              "cap_mknod",
+	LEASE:              "cap_lease",
+	AUDIT_WRITE:        "cap_audit_write",
+	AUDIT_CONTROL:      "cap_audit_control",
+	SETFCAP:            "cap_setfcap",
+	MAC_OVERRIDE:       "cap_mac_override",
+	MAC_ADMIN:          "cap_mac_admin",
+	SYSLOG:             "cap_syslog",
+	WAKE_ALARM:         "cap_wake_alarm",
+	BLOCK_SUSPEND:      "cap_block_suspend",
+	AUDIT_READ:         "cap_audit_read",
+	PERFMON:            "cap_perfmon",
+	BPF:                "cap_bpf",
+	CHECKPOINT_RESTORE: "cap_checkpoint_restore",
+}
+
+// nameToValue is the inverse of valueToName, built at init time.
+var nameToValue = func() map[string]Value {
+	m := make(map[string]Value, len(valueToName))
+	for v, name := range valueToName {
+		m[name] = Value(v)
+	}
+	return m
+}()
@@ -0,0 +1,61 @@
+package cap
+
+import (
+	"errors"
+	"syscall"
+)
+
+// GetNSOwner returns the user namespace root uid that c will be
+// tagged with when next written out via SetFile/SetFd. Zero
+// indicates "current user namespace", in which case the file
+// capability is written using the plain VFS_CAP_REVISION_2 layout
+// rather than the rootid-tagged VFS_CAP_REVISION_3 one.
+func (c *Set) GetNSOwner() (int, error) {
+	if c == nil || len(c.flat) == 0 {
+		return 0, ErrBadSet
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.nsRoot, nil
+}
+
+// SetNSOwner tags c with the user namespace root uid to use the next
+// time it is written out as a file capability. Passing uid zero
+// reverts c to the unnamespaced VFS_CAP_REVISION_2 layout.
+func (c *Set) SetNSOwner(uid int) error {
+	if c == nil || len(c.flat) == 0 {
+		return ErrBadSet
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nsRoot = uid
+	return nil
+}
+
+// ErrNotOwnUID indicates a uid was requested that does not match the
+// calling thread's own effective uid; see GetUIDCap.
+var ErrNotOwnUID = errors.New("ambient capability policy is only readable for the caller's own uid")
+
+// GetUIDCap returns the ambient capability set that applies to
+// processes executing as uid. The kernel only exposes PR_CAP_AMBIENT
+// state for the calling thread's own credentials, so this is only
+// meaningful, and only supported, when uid matches the caller's
+// effective uid; any other uid returns ErrNotOwnUID.
+func GetUIDCap(uid int) (*Set, error) {
+	if uid != syscall.Geteuid() {
+		return nil, ErrNotOwnUID
+	}
+	c := NewSet()
+	for v := Value(0); v < Value(maxValues); v++ {
+		ok, err := GetAmbient(v)
+		if err != nil {
+			break
+		}
+		if ok {
+			if err := c.SetFlag(Inheritable, true, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,74 @@
+package cap
+
+// defines from uapi/linux/prctl.h
+const (
+	PR_GET_KEEPCAPS = 7
+	PR_SET_KEEPCAPS = 8
+
+	PR_GET_SECUREBITS = 27
+	PR_SET_SECUREBITS = 28
+
+	PR_SET_NO_NEW_PRIVS = 38
+	PR_GET_NO_NEW_PRIVS = 39
+)
+
+// SecBits is a bitmask of the kernel's securebits. See
+// linux/securebits.h.
+type SecBits uint
+
+// The securebits recognized by the kernel.
+const (
+	SecbitNoRoot                  SecBits = 1 << 0
+	SecbitNoRootLocked            SecBits = 1 << 1
+	SecbitNoSetUIDFixup           SecBits = 1 << 2
+	SecbitNoSetUIDFixupLocked     SecBits = 1 << 3
+	SecbitKeepCaps                SecBits = 1 << 4
+	SecbitKeepCapsLocked          SecBits = 1 << 5
+	SecbitNoCapAmbientRaise       SecBits = 1 << 6
+	SecbitNoCapAmbientRaiseLocked SecBits = 1 << 7
+)
+
+// GetSecbits returns the calling thread's current securebits.
+func GetSecbits() (SecBits, error) {
+	r, err := prctlrcall(PR_GET_SECUREBITS, 0, 0)
+	return SecBits(r), err
+}
+
+// Set installs sb as the calling thread's securebits.
+func (sb SecBits) Set() error {
+	_, err := prctlwcall(PR_SET_SECUREBITS, uintptr(sb), 0)
+	return err
+}
+
+// GetNoNewPrivs returns whether the calling thread's no_new_privs
+// attribute is set.
+func GetNoNewPrivs() (bool, error) {
+	r, err := prctlrcall(PR_GET_NO_NEW_PRIVS, 0, 0)
+	return r != 0, err
+}
+
+// SetNoNewPrivs sets the calling thread's no_new_privs attribute.
+// This is a one-way switch: once set it cannot be cleared again.
+func SetNoNewPrivs() error {
+	_, err := prctlwcall(PR_SET_NO_NEW_PRIVS, 1, 0)
+	return err
+}
+
+// GetKeepCaps returns whether the calling thread's keep-capabilities
+// flag is set.
+func GetKeepCaps() (bool, error) {
+	r, err := prctlrcall(PR_GET_KEEPCAPS, 0, 0)
+	return r != 0, err
+}
+
+// SetKeepCaps sets or clears the calling thread's keep-capabilities
+// flag, which controls whether a uid change away from zero drops the
+// thread's Permitted and Effective capabilities.
+func SetKeepCaps(enable bool) error {
+	v := uintptr(0)
+	if enable {
+		v = 1
+	}
+	_, err := prctlwcall(PR_SET_KEEPCAPS, v, 0)
+	return err
+}
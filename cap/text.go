@@ -0,0 +1,200 @@
+package cap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrBadText indicates a capability text expression could not be
+// parsed.
+var ErrBadText = errors.New("unable to parse capability text")
+
+// FromName resolves name (matched case insensitively, with or
+// without the "cap_" prefix) to its Value.
+func FromName(name string) (Value, error) {
+	v, ok := nameToValue[normalizeName(name)]
+	if !ok {
+		return 0, ErrBadValue
+	}
+	return v, nil
+}
+
+// String returns the canonical "cap_foo" form of a Value, or a
+// "cap(nnn)" placeholder for a Value this build has no name for.
+func (v Value) String() string {
+	if int(v) < len(valueToName) {
+		return valueToName[v]
+	}
+	return fmt.Sprintf("cap(%d)", uint(v))
+}
+
+func normalizeName(name string) string {
+	name = strings.ToLower(name)
+	if !strings.HasPrefix(name, "cap_") {
+		name = "cap_" + name
+	}
+	return name
+}
+
+// FromText parses a cap_from_text(3) style expression - a space
+// separated list of clauses of the form "cap-list op flags" - into a
+// capability Set. cap-list is a comma separated list of capability
+// names (or the keyword "all"); op is one of "=", "+" or "-"; flags
+// is one or more of "e", "i", "p". Clauses are applied left to
+// right; within a clause, "=" first clears the named flags for the
+// listed capabilities before (re)setting them.
+func FromText(s string) (*Set, error) {
+	c := NewSet()
+	for _, clause := range strings.Fields(s) {
+		if err := applyClause(c, clause); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func applyClause(c *Set, clause string) error {
+	opIdx := strings.IndexAny(clause, "=+-")
+	if opIdx <= 0 {
+		return ErrBadText
+	}
+	target, err := clauseValues(clause[:opIdx])
+	if err != nil {
+		return err
+	}
+	rest := clause[opIdx:]
+	for len(rest) > 0 {
+		op := rest[0]
+		rest = rest[1:]
+		end := 0
+		for end < len(rest) && strings.ContainsRune("eip", rune(rest[end])) {
+			end++
+		}
+		flags := rest[:end]
+		rest = rest[end:]
+		if flags == "" {
+			return ErrBadText
+		}
+		for _, f := range flags {
+			vec, err := flagOf(f)
+			if err != nil {
+				return err
+			}
+			if op == '=' {
+				if err := c.SetFlag(vec, false, target...); err != nil {
+					return err
+				}
+			}
+			if err := c.SetFlag(vec, op != '-', target...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func flagOf(f rune) (Flag, error) {
+	switch f {
+	case 'e':
+		return Effective, nil
+	case 'i':
+		return Inheritable, nil
+	case 'p':
+		return Permitted, nil
+	}
+	return 0, ErrBadText
+}
+
+func clauseValues(list string) ([]Value, error) {
+	var vals []Value
+	for _, n := range strings.Split(list, ",") {
+		if strings.EqualFold(n, "all") {
+			return allValues(), nil
+		}
+		v, err := FromName(n)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+func allValues() []Value {
+	vals := make([]Value, maxValues)
+	for i := range vals {
+		vals[i] = Value(i)
+	}
+	return vals
+}
+
+// String renders c using the same syntax accepted by FromText,
+// grouping Values that share an identical (Effective, Inheritable,
+// Permitted) mask into the shortest canonical list of clauses.
+func (c *Set) String() string {
+	if c == nil || len(c.flat) == 0 {
+		return ""
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	type mask struct{ e, i, p bool }
+	groups := map[mask][]Value{}
+	for v := Value(0); v < Value(maxValues); v++ {
+		offset, bit, err := bitOf(Effective, v)
+		if err != nil {
+			break
+		}
+		m := mask{
+			e: c.flat[offset][Effective]&bit != 0,
+			i: c.flat[offset][Inheritable]&bit != 0,
+			p: c.flat[offset][Permitted]&bit != 0,
+		}
+		if !m.e && !m.i && !m.p {
+			continue
+		}
+		groups[m] = append(groups[m], v)
+	}
+
+	order := []mask{
+		{true, true, true},
+		{true, true, false},
+		{true, false, true},
+		{false, true, true},
+		{true, false, false},
+		{false, true, false},
+		{false, false, true},
+	}
+	var clauses []string
+	for _, m := range order {
+		vals, ok := groups[m]
+		if !ok {
+			continue
+		}
+		sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+		var list string
+		if len(vals) == int(maxValues) {
+			list = "all"
+		} else {
+			names := make([]string, len(vals))
+			for i, v := range vals {
+				names[i] = v.String()
+			}
+			list = strings.Join(names, ",")
+		}
+		flags := ""
+		if m.e {
+			flags += "e"
+		}
+		if m.i {
+			flags += "i"
+		}
+		if m.p {
+			flags += "p"
+		}
+		clauses = append(clauses, list+"="+flags)
+	}
+	return strings.Join(clauses, " ")
+}